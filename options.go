@@ -0,0 +1,83 @@
+package yadsec
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// Option configures a Yadsec built via New.
+type Option func(*yadsecConfig)
+
+type yadsecConfig struct {
+	fs         fs.FS
+	secretsDir string
+	dotenvPath string
+	configPath string
+}
+
+// WithFS overrides the filesystem used to resolve __FILE paths, the
+// secrets directory, the dotenv file and the config file. It defaults to
+// the OS root filesystem.
+func WithFS(fsys fs.FS) Option {
+	return func(c *yadsecConfig) {
+		c.fs = fsys
+	}
+}
+
+// WithSecretsDir overrides the Docker-style secrets directory used to
+// resolve __SECRET suffixed variables. It defaults to "/run/secrets/".
+func WithSecretsDir(dir string) Option {
+	return func(c *yadsecConfig) {
+		c.secretsDir = dir
+	}
+}
+
+// WithDotenv adds a ".env"-style KEY=VALUE file as a config source,
+// consulted after explicit environment variables but before __SECRET and
+// config file values.
+func WithDotenv(path string) Option {
+	return func(c *yadsecConfig) {
+		c.dotenvPath = path
+	}
+}
+
+// WithConfigFile adds a flat key/value config file (JSON, YAML or TOML,
+// selected by the path's extension) as the lowest-priority source,
+// consulted after environment, dotenv and secret values but before struct
+// tag defaults.
+func WithConfigFile(path string) Option {
+	return func(c *yadsecConfig) {
+		c.configPath = path
+	}
+}
+
+// New builds a Yadsec configured with opts. File-backed sources (dotenv,
+// config file) are read and parsed immediately, so a missing or
+// malformed file is reported here rather than during Load.
+func New(opts ...Option) (*Yadsec, error) {
+	cfg := yadsecConfig{secretsDir: "/run/secrets/"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	y := &Yadsec{fs: cfg.fs, secretsDir: cfg.secretsDir}
+	fsys := y.fsOrDefault()
+
+	if cfg.dotenvPath != "" {
+		src, err := loadDotenvSource(fsys, cfg.dotenvPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load dotenv file %s: %v", cfg.dotenvPath, err)
+		}
+		y.dotenv = src
+	}
+
+	if cfg.configPath != "" {
+		src, err := loadConfigFileSource(fsys, cfg.configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %v", cfg.configPath, err)
+		}
+		y.configFile = src
+	}
+
+	return y, nil
+}
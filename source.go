@@ -0,0 +1,216 @@
+package yadsec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Source is a single layer of configuration values, keyed by the same
+// names used in `env` struct tags. The dotenv and config file sources
+// below are read-only snapshots that parse their input once, at
+// construction time, so a malformed file is reported immediately instead
+// of deep inside Load. SecretsSource instead reads the secret file on
+// every Lookup.
+type Source interface {
+	// Lookup returns the value for key and whether it was present.
+	Lookup(key string) (string, bool)
+}
+
+// EnvSource reads values directly from process environment variables.
+type EnvSource struct{}
+
+func (EnvSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// mapSource is a Source backed by an in-memory map, shared by the dotenv
+// and config file sources below.
+type mapSource map[string]string
+
+func (m mapSource) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// SecretsSource reads values from a Docker-style secrets directory, where
+// each file name is a variable name and its (trimmed) contents is the
+// value.
+type SecretsSource struct {
+	fs  fs.FS
+	dir string
+}
+
+// NewSecretsSource returns a SecretsSource that reads files named after
+// the looked-up key out of dir on fsys. A nil fsys defaults to the OS
+// root filesystem.
+func NewSecretsSource(fsys fs.FS, dir string) *SecretsSource {
+	return &SecretsSource{fs: fsys, dir: dir}
+}
+
+func (s *SecretsSource) Lookup(key string) (string, bool) {
+	fsys := s.fs
+	if fsys == nil {
+		fsys = os.DirFS("/")
+	}
+
+	value, err := readTrimmedFile(fsys, s.dir+key)
+	if err != nil || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+// loadDotenvSource reads a ".env"-style file of KEY=VALUE lines from fsys.
+// Blank lines and lines starting with "#" are ignored, and values may be
+// wrapped in matching single or double quotes.
+func loadDotenvSource(fsys fs.FS, path string) (Source, error) {
+	path = strings.TrimPrefix(path, "/")
+	if !fs.ValidPath(path) {
+		return nil, fmt.Errorf("invalid path %s", path)
+	}
+
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(mapSource)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		values[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+	}
+
+	return values, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' && last == '"') || (first == '\'' && last == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// loadConfigFileSource reads a flat key/value config file from fsys.
+// The format is chosen from the file extension: ".json" via encoding/json,
+// ".yaml"/".yml" via loadYAMLSource and ".toml" via loadTOMLSource. All
+// three only support a single flat mapping of string keys to values,
+// matching what every other Source in this package deals in - nested
+// tables/mappings aren't meaningful here since env values are always
+// strings. In JSON every value must be a string; numbers, booleans and
+// null are rejected. In YAML and TOML the text after the separator is
+// taken as the value.
+func loadConfigFileSource(fsys fs.FS, path string) (Source, error) {
+	trimmed := strings.TrimPrefix(path, "/")
+	if !fs.ValidPath(trimmed) {
+		return nil, fmt.Errorf("invalid path %s", trimmed)
+	}
+
+	b, err := fs.ReadFile(fsys, trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		values := make(mapSource)
+		if err := json.Unmarshal(b, &values); err != nil {
+			return nil, fmt.Errorf("invalid json: %v", err)
+		}
+		return values, nil
+	case ".yaml", ".yml":
+		return loadYAMLSource(b)
+	case ".toml":
+		return loadTOMLSource(b)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q", ext)
+	}
+}
+
+// loadYAMLSource parses a flat YAML mapping ("KEY: value" per line) into a
+// Source. Blank lines, "#" comments and trailing inline comments are
+// ignored, and values may be wrapped in matching quotes. Indented lines -
+// i.e. nested mappings or sequences - are rejected rather than flattened,
+// since a nested structure can't be represented as a single string value.
+func loadYAMLSource(b []byte) (Source, error) {
+	values := make(mapSource)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("invalid yaml line %q: indented/nested mappings are not supported", line)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid yaml line %q: only flat \"key: value\" mappings are supported", line)
+		}
+		value = stripTrailingComment(strings.TrimSpace(value))
+		values[strings.TrimSpace(key)] = unquote(value)
+	}
+	return values, nil
+}
+
+// loadTOMLSource parses a flat TOML document ("KEY = value" per line) into
+// a Source. Blank lines and "#" comments are ignored, values may be
+// wrapped in matching quotes, and a trailing "# comment" after a value is
+// stripped. Tables ("[section]") are not supported.
+func loadTOMLSource(b []byte) (Source, error) {
+	values := make(mapSource)
+	for _, line := range strings.Split(string(b), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			return nil, fmt.Errorf("toml tables are not supported: %q", line)
+		}
+
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid toml line %q", line)
+		}
+		value = stripTrailingComment(strings.TrimSpace(value))
+		values[strings.TrimSpace(key)] = unquote(value)
+	}
+	return values, nil
+}
+
+// stripTrailingComment removes a trailing "# comment" from value, treating
+// "#" as a comment marker only when it appears outside of a quoted span and
+// is preceded by whitespace (or starts the value) - so a quoted value that
+// itself contains "#" is left untouched.
+func stripTrailingComment(value string) string {
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t'):
+			return strings.TrimRight(value[:i], " \t")
+		}
+	}
+	return value
+}
@@ -0,0 +1,81 @@
+package yadsec
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Usage writes a formatted table describing the environment variables that
+// config declares via `env` struct tags: the variable name (and its
+// aliases), its Go type, whether it is required, its default value, and its
+// env-description. It is intended for building --help output.
+func Usage(config any, w io.Writer) error {
+	typ := reflect.TypeOf(config)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a struct or a pointer to a struct")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIABLE\tTYPE\tREQUIRED\tDEFAULT\tDESCRIPTION")
+
+	if err := writeUsageFields(tw, typ, "", false); err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// writeUsageFields recurses into nested struct fields the same way
+// loadStruct does, so that prefixed child variables show up under their
+// full, prefixed name and inherit an ancestor's `required`.
+func writeUsageFields(tw *tabwriter.Writer, typ reflect.Type, prefix string, parentRequired bool) error {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		rawKey := field.Tag.Get("env")
+
+		if field.Type.Kind() == reflect.Struct && !isLeafStruct(field.Type) {
+			childPrefix := prefix
+			childRequired := parentRequired
+			if rawKey != "" {
+				aliases, required, isPrefix, _ := parseEnvTag(rawKey)
+				if isPrefix && len(aliases) > 0 {
+					childPrefix = prefix + aliases[0]
+				}
+				childRequired = parentRequired || required
+			}
+			if err := writeUsageFields(tw, field.Type, childPrefix, childRequired); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if rawKey == "" {
+			continue
+		}
+		aliases, required, _, _ := parseEnvTag(rawKey)
+		if len(aliases) == 0 {
+			continue
+		}
+		required = required || parentRequired
+
+		names := make([]string, len(aliases))
+		for j, alias := range aliases {
+			names[j] = prefix + alias
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\t%s\n",
+			strings.Join(names, ","),
+			field.Type,
+			required,
+			field.Tag.Get("env-default"),
+			field.Tag.Get("env-description"),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package yadsec
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+// Watcher holds a live, periodically-reloaded snapshot of a config
+// struct of type T, populated by Watch. Get is safe to call from any
+// goroutine.
+type Watcher[T any] struct {
+	mu    sync.RWMutex
+	value *T
+}
+
+// Get returns the current snapshot.
+func (w *Watcher[T]) Get() *T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.value
+}
+
+func (w *Watcher[T]) swap(v *T) {
+	w.mu.Lock()
+	w.value = v
+	w.mu.Unlock()
+}
+
+// clock abstracts time.NewTicker so tests can drive Watch's poll loop
+// without sleeping.
+type clock interface {
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// WatchOption configures Watch.
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval time.Duration
+	clock    clock
+}
+
+// WithPollInterval overrides how often Watch re-stats the watched files.
+// It defaults to 5 seconds.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = d
+	}
+}
+
+func withClock(c clock) WatchOption {
+	return func(o *watchOptions) {
+		o.clock = c
+	}
+}
+
+// Watch loads config of type T via y, then polls the files that backed
+// it - the secrets directory and any __FILE paths referenced by the
+// struct - for changes. On a detected change it reloads into a fresh
+// copy, atomically swaps it into the returned Watcher, and invokes
+// onChange. Fields tagged `env:"...,noreload"` keep their original,
+// bootstrap-time value across reloads instead of being re-read.
+//
+// Watching stops when ctx is canceled. Each reload swaps in a new value,
+// so consumers should call (*Watcher[T]).Get each time they read the
+// config rather than holding on to a pointer an earlier Get returned.
+func Watch[T any](ctx context.Context, y *Yadsec, onChange func(error), opts ...WatchOption) (*Watcher[T], error) {
+	options := watchOptions{interval: defaultPollInterval, clock: realClock{}}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	paths := collectWatchPaths(typ, "", y.secretsDir)
+
+	initial := new(T)
+	if err := y.Load(initial); err != nil {
+		return nil, err
+	}
+	w := &Watcher[T]{value: initial}
+
+	fsys := y.fsOrDefault()
+	last := statAll(fsys, paths)
+
+	ticks, stop := options.clock.NewTicker(options.interval)
+	go func() {
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				next := statAll(fsys, paths)
+				if reflect.DeepEqual(last, next) {
+					continue
+				}
+				last = next
+
+				reloaded := new(T)
+				if err := y.Load(reloaded); err != nil {
+					onChange(err)
+					continue
+				}
+				preserveNoReload(reflect.ValueOf(w.Get()).Elem(), reflect.ValueOf(reloaded).Elem())
+				w.swap(reloaded)
+				onChange(nil)
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// collectWatchPaths walks typ the same way loadStruct does, returning
+// the secrets-dir path for every alias of every leaf field, plus the
+// secret name and __FILE path currently set for that alias, if any.
+func collectWatchPaths(typ reflect.Type, prefix string, secretsDir string) []string {
+	var paths []string
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		rawKey := field.Tag.Get("env")
+
+		if field.Type.Kind() == reflect.Struct && !isLeafStruct(field.Type) {
+			childPrefix := prefix
+			if rawKey != "" {
+				aliases, _, isPrefix, _ := parseEnvTag(rawKey)
+				if isPrefix && len(aliases) > 0 {
+					childPrefix = prefix + aliases[0]
+				}
+			}
+			paths = append(paths, collectWatchPaths(field.Type, childPrefix, secretsDir)...)
+			continue
+		}
+
+		if rawKey == "" {
+			continue
+		}
+		aliases, _, _, _ := parseEnvTag(rawKey)
+		for _, alias := range aliases {
+			key := prefix + alias
+			paths = append(paths, secretsDir+key)
+			if name, ok := os.LookupEnv(secretEnvvar(key)); ok && name != "" {
+				paths = append(paths, secretsDir+name)
+			}
+			if path, ok := os.LookupEnv(fileEnvvar(key)); ok && path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return paths
+}
+
+// statAll stats each of paths on fsys, skipping any that don't currently
+// exist or aren't valid fs.FS paths. Its result is only ever compared for
+// equality, so a missing file simply contributes nothing to map.
+func statAll(fsys fs.FS, paths []string) map[string]time.Time {
+	stats := make(map[string]time.Time, len(paths))
+	for _, p := range paths {
+		trimmed := strings.TrimPrefix(p, "/")
+		if !fs.ValidPath(trimmed) {
+			continue
+		}
+		info, err := fs.Stat(fsys, trimmed)
+		if err != nil {
+			continue
+		}
+		stats[p] = info.ModTime()
+	}
+	return stats
+}
+
+// preserveNoReload copies every field tagged `env:"...,noreload"` from
+// old into next, recursing into nested structs the same way loadStruct
+// does, so reload can't race bootstrap-only values.
+func preserveNoReload(old, next reflect.Value) {
+	typ := old.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		if field.Type.Kind() == reflect.Struct && !isLeafStruct(field.Type) {
+			preserveNoReload(old.Field(i), next.Field(i))
+			continue
+		}
+
+		rawKey := field.Tag.Get("env")
+		if rawKey == "" {
+			continue
+		}
+		if _, _, _, noReload := parseEnvTag(rawKey); noReload {
+			next.Field(i).Set(old.Field(i))
+		}
+	}
+}
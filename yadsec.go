@@ -3,20 +3,35 @@ package yadsec
 import (
 	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	fileVarSuffix   = "__FILE"
 	secretVarSuffix = "__SECRET"
+
+	defaultTimeLayout     = time.RFC3339
+	defaultSliceSeparator = ","
+	defaultMapSeparator   = ":"
+	mapEntrySeparator     = ","
 )
 
+// Setter can be implemented by a field's type to take over parsing of its
+// own value, in place of the built-in type handling in parseEnvvar.
+type Setter interface {
+	UnmarshalEnv(value string) error
+}
+
 type Yadsec struct {
 	fs         fs.FS
 	secretsDir string
+	dotenv     Source
+	configFile Source
 }
 
 func Load(config any) error {
@@ -25,37 +40,82 @@ func Load(config any) error {
 	return y.load(config)
 }
 
+// Load populates config using this Yadsec's configured sources. Unlike
+// the package-level Load, it respects whatever Options it was built with
+// via New.
+func (y *Yadsec) Load(config any) error {
+	return y.load(config)
+}
+
 func (y Yadsec) load(config any) error {
-	var (
-		val = reflect.ValueOf(config).Elem()
-		typ = reflect.TypeOf(config).Elem()
-	)
+	val := reflect.ValueOf(config).Elem()
+	return y.loadStruct(val, "", false, map[reflect.Type]bool{})
+}
+
+// loadStruct populates val, recursing into embedded and named struct
+// fields. prefix is prepended to every env name at this level (set by an
+// ancestor's `env:"...,prefix"` tag), parentRequired propagates an
+// ancestor's `required` down to descendants that don't set their own, and
+// seen tracks the struct types on the current path to reject cycles.
+func (y Yadsec) loadStruct(val reflect.Value, prefix string, parentRequired bool, seen map[reflect.Type]bool) error {
+	typ := val.Type()
+	if seen[typ] {
+		return fmt.Errorf("cyclic struct type %s", typ)
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
 
 	for i := range typ.NumField() {
 		field := typ.Field(i)
+		fieldVal := val.Field(i)
 		rawKey := field.Tag.Get("env")
+
+		if fieldVal.Kind() == reflect.Struct && !isLeafStruct(fieldVal.Type()) {
+			childPrefix := prefix
+			childRequired := parentRequired
+			if rawKey != "" {
+				aliases, required, isPrefix, _ := parseEnvTag(rawKey)
+				if isPrefix && len(aliases) > 0 {
+					childPrefix = prefix + aliases[0]
+				}
+				childRequired = parentRequired || required
+			}
+			if err := y.loadStruct(fieldVal, childPrefix, childRequired, seen); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if rawKey == "" {
 			continue
 		}
-		keys := strings.Split(rawKey, ",")
+		aliases, required, _, _ := parseEnvTag(rawKey)
+		if len(aliases) == 0 {
+			continue
+		}
+		required = required || parentRequired
 
-		envKey := keys[0]
+		prefixedAliases := make([]string, len(aliases))
+		for j, alias := range aliases {
+			prefixedAliases[j] = prefix + alias
+		}
+		envKey := prefixedAliases[0]
 
-		envValue, err := y.readEnvvar(envKey)
+		envValue, err := y.readEnvvar(prefixedAliases)
 		if err != nil {
 			return fmt.Errorf("failed to read variable %s: %v", envKey, err)
 		}
 		if envValue == "" {
-			if contains("required", keys) {
+			if def, ok := field.Tag.Lookup("env-default"); ok {
+				envValue = def
+			} else if required {
 				return fmt.Errorf("%s is required", envKey)
 			} else {
 				continue
 			}
 		}
 
-		fieldVal := val.Field(i)
-
-		err = parseEnvvar(fieldVal, envValue, envKey)
+		err = parseEnvvar(field, fieldVal, envValue, envKey)
 		if err != nil {
 			return fmt.Errorf("failed to parse variable: %v", err)
 		}
@@ -63,7 +123,61 @@ func (y Yadsec) load(config any) error {
 	return nil
 }
 
-func (y Yadsec) readEnvvar(key string) (string, error) {
+// parseEnvTag splits an `env` struct tag into its ordered alias names and
+// its reserved option words: "required", "prefix" (only meaningful on
+// struct-kind fields, see loadStruct) and "noreload" (only meaningful to
+// Watch). Aliases are tried in written order by readEnvvar, so the first
+// one set wins.
+func parseEnvTag(rawKey string) (aliases []string, required bool, prefix bool, noReload bool) {
+	for _, tok := range strings.Split(rawKey, ",") {
+		switch tok {
+		case "required":
+			required = true
+		case "prefix":
+			prefix = true
+		case "noreload":
+			noReload = true
+		default:
+			aliases = append(aliases, tok)
+		}
+	}
+	return aliases, required, prefix, noReload
+}
+
+// isLeafStruct reports whether t is a struct-kind type that parseEnvvar
+// handles directly and loadStruct should therefore not recurse into, such
+// as time.Time or a type implementing Setter.
+func isLeafStruct(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	return reflect.PointerTo(t).Implements(setterType)
+}
+
+var setterType = reflect.TypeOf((*Setter)(nil)).Elem()
+
+// readEnvvar tries each alias in order and returns the value of the first
+// one that is set, honoring the __FILE and __SECRET suffix variants of
+// each alias along the way.
+func (y Yadsec) readEnvvar(keys []string) (string, error) {
+	for _, key := range keys {
+		value, err := y.readEnvvarAlias(key)
+		if err != nil {
+			return "", err
+		}
+		if value != "" {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// readEnvvarAlias resolves key through the full source pipeline, in
+// priority order: an explicit environment variable, the configured dotenv
+// source, the __SECRET suffix variant (read from the Docker-style secrets
+// directory), the __FILE suffix variant (read from the path it names), and
+// finally the configured config file source.
+func (y Yadsec) readEnvvarAlias(key string) (string, error) {
 	var (
 		file   = fileEnvvar(key)
 		secret = secretEnvvar(key)
@@ -77,21 +191,26 @@ func (y Yadsec) readEnvvar(key string) (string, error) {
 		return os.Getenv(key), nil
 	}
 
+	if y.dotenv != nil {
+		if value, ok := y.dotenv.Lookup(key); ok && value != "" {
+			return value, nil
+		}
+	}
+
 	if isEnvSet(secret) {
-		defer os.Unsetenv(secret)
-		s := os.Getenv(secret)
-		var secretName string
-		if s == "" {
+		secretName := os.Getenv(secret)
+		if secretName == "" {
 			secretName = key
-		} else {
-			secretName = s
 		}
 
-		os.Setenv(file, y.secretsDir+secretName)
+		value, ok := y.secrets().Lookup(secretName)
+		if !ok {
+			return "", fmt.Errorf("failed to read secret %s", secretName)
+		}
+		return value, nil
 	}
 
 	if isEnvSet(file) {
-		defer os.Unsetenv(file)
 		path := os.Getenv(file)
 		value, err := y.readFile(path)
 		if err != nil {
@@ -103,6 +222,12 @@ func (y Yadsec) readEnvvar(key string) (string, error) {
 		return value, err
 	}
 
+	if y.configFile != nil {
+		if value, ok := y.configFile.Lookup(key); ok {
+			return value, nil
+		}
+	}
+
 	return "", nil
 }
 
@@ -110,14 +235,24 @@ func fileEnvvar(key string) string {
 	return key + fileVarSuffix
 }
 
-func (y Yadsec) readFile(path string) (string, error) {
-	var fd fs.FS
+// secrets returns the Source backing this Yadsec's Docker-style secrets
+// directory.
+func (y Yadsec) secrets() *SecretsSource {
+	return NewSecretsSource(y.fsOrDefault(), y.secretsDir)
+}
+
+func (y Yadsec) fsOrDefault() fs.FS {
 	if y.fs != nil {
-		fd = y.fs
-	} else {
-		fd = os.DirFS("/")
+		return y.fs
 	}
+	return os.DirFS("/")
+}
+
+func (y Yadsec) readFile(path string) (string, error) {
+	return readTrimmedFile(y.fsOrDefault(), path)
+}
 
+func readTrimmedFile(fd fs.FS, path string) (string, error) {
 	path = strings.TrimPrefix(path, "/")
 
 	if !fs.ValidPath(path) {
@@ -151,17 +286,83 @@ func secretEnvvar(key string) string {
 	return key + secretVarSuffix
 }
 
-func parseEnvvar(fieldVal reflect.Value, envValue string, envTag string) error {
+// parseEnvvar converts envValue into fieldVal, dispatching on the field's
+// type. It applies uniformly no matter whether envValue came from the
+// environment, a __FILE path, or a __SECRET. field is the struct field
+// being populated, used to read type-specific tags such as env-layout and
+// env-separator, and envTag is the alias name used in error messages.
+func parseEnvvar(field reflect.StructField, fieldVal reflect.Value, envValue string, envTag string) error {
+	if fieldVal.CanAddr() {
+		if setter, ok := fieldVal.Addr().Interface().(Setter); ok {
+			if err := setter.UnmarshalEnv(envValue); err != nil {
+				return fmt.Errorf("invalid value for %s: %v", envTag, err)
+			}
+			return nil
+		}
+	}
+
+	switch fieldVal.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(envValue)
+		if err != nil {
+			return fmt.Errorf("invalid duration value for %s: %v", envTag, err)
+		}
+		fieldVal.SetInt(int64(d))
+		return nil
+	case time.Time:
+		layout := field.Tag.Get("env-layout")
+		if layout == "" {
+			layout = defaultTimeLayout
+		}
+		t, err := time.Parse(layout, envValue)
+		if err != nil {
+			return fmt.Errorf("invalid time value for %s: %v", envTag, err)
+		}
+		fieldVal.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch fieldVal.Type() {
+	case reflect.TypeOf((*time.Location)(nil)):
+		loc, err := time.LoadLocation(envValue)
+		if err != nil {
+			return fmt.Errorf("invalid location value for %s: %v", envTag, err)
+		}
+		fieldVal.Set(reflect.ValueOf(loc))
+		return nil
+	case reflect.TypeOf((*url.URL)(nil)):
+		u, err := url.Parse(envValue)
+		if err != nil {
+			return fmt.Errorf("invalid url value for %s: %v", envTag, err)
+		}
+		fieldVal.Set(reflect.ValueOf(u))
+		return nil
+	}
+
 	switch fieldVal.Kind() {
 	case reflect.String:
 		fieldVal.SetString(envValue)
 		return nil
-	case reflect.Int:
-		intVal, err := strconv.Atoi(envValue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(envValue, 10, fieldVal.Type().Bits())
 		if err != nil {
 			return fmt.Errorf("invalid integer value for %s: %v", envTag, err)
 		}
-		fieldVal.SetInt(int64(intVal))
+		fieldVal.SetInt(intVal)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(envValue, 10, fieldVal.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer value for %s: %v", envTag, err)
+		}
+		fieldVal.SetUint(uintVal)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(envValue, fieldVal.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float value for %s: %v", envTag, err)
+		}
+		fieldVal.SetFloat(floatVal)
 		return nil
 	case reflect.Bool:
 		boolVal, err := strconv.ParseBool(envValue)
@@ -170,11 +371,64 @@ func parseEnvvar(fieldVal reflect.Value, envValue string, envTag string) error {
 		}
 		fieldVal.SetBool(boolVal)
 		return nil
+	case reflect.Slice:
+		return parseSlice(field, fieldVal, envValue, envTag)
+	case reflect.Map:
+		return parseMap(field, fieldVal, envValue, envTag)
 	default:
 		return fmt.Errorf("unsupported field type for %s", envTag)
 	}
 }
 
+// parseSlice splits envValue on the field's env-separator (default ",")
+// and parses each element as the slice's element type.
+func parseSlice(field reflect.StructField, fieldVal reflect.Value, envValue string, envTag string) error {
+	separator := field.Tag.Get("env-separator")
+	if separator == "" {
+		separator = defaultSliceSeparator
+	}
+
+	parts := strings.Split(envValue, separator)
+	slice := reflect.MakeSlice(fieldVal.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := parseEnvvar(field, slice.Index(i), part, envTag); err != nil {
+			return err
+		}
+	}
+	fieldVal.Set(slice)
+	return nil
+}
+
+// parseMap splits envValue into comma-separated "key<sep>value" entries,
+// where <sep> defaults to ":" and is overridable via env-separator, e.g.
+// "k1:v1,k2:v2".
+func parseMap(field reflect.StructField, fieldVal reflect.Value, envValue string, envTag string) error {
+	kvSeparator := field.Tag.Get("env-separator")
+	if kvSeparator == "" {
+		kvSeparator = defaultMapSeparator
+	}
+
+	m := reflect.MakeMap(fieldVal.Type())
+	for _, entry := range strings.Split(envValue, mapEntrySeparator) {
+		kv := strings.SplitN(entry, kvSeparator, 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid map entry %q for %s", entry, envTag)
+		}
+
+		key := reflect.New(fieldVal.Type().Key()).Elem()
+		if err := parseEnvvar(field, key, kv[0], envTag); err != nil {
+			return err
+		}
+		value := reflect.New(fieldVal.Type().Elem()).Elem()
+		if err := parseEnvvar(field, value, kv[1], envTag); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, value)
+	}
+	fieldVal.Set(m)
+	return nil
+}
+
 func isEnvSet(key string) bool {
 	_, set := os.LookupEnv(key)
 	return set
@@ -192,12 +446,3 @@ func mutuallyExclusive(values ...bool) bool {
 	}
 	return count <= 1
 }
-
-func contains[T comparable](elem T, slice []T) bool {
-	for _, v := range slice {
-		if elem == v {
-			return true
-		}
-	}
-	return false
-}
@@ -1,10 +1,16 @@
 package yadsec
 
 import (
+	"bytes"
+	"context"
+	"net/url"
 	"os"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
+	"time"
 )
 
 type TestCase[T comparable] struct {
@@ -183,6 +189,704 @@ func TestRequiredFields(t *testing.T) {
 	}
 }
 
+func Test_EnvAliases(t *testing.T) {
+	type xyz struct {
+		Key string `env:"PRIMARY_KEY,LEGACY_KEY,FALLBACK_KEY"`
+	}
+	type required struct {
+		Key string `env:"PRIMARY_KEY,LEGACY_KEY,required"`
+	}
+
+	tests := []TestCase[xyz]{
+		{
+			name: "no aliases set",
+			want: xyz{},
+		},
+		{
+			name: "only primary set",
+			env: map[string]string{
+				"PRIMARY_KEY": "primary",
+			},
+			want: xyz{Key: "primary"},
+		},
+		{
+			name: "only legacy set",
+			env: map[string]string{
+				"LEGACY_KEY": "legacy",
+			},
+			want: xyz{Key: "legacy"},
+		},
+		{
+			name: "primary takes precedence over legacy",
+			env: map[string]string{
+				"PRIMARY_KEY": "primary",
+				"LEGACY_KEY":  "legacy",
+			},
+			want: xyz{Key: "primary"},
+		},
+		{
+			name: "legacy takes precedence over fallback",
+			env: map[string]string{
+				"LEGACY_KEY":   "legacy",
+				"FALLBACK_KEY": "fallback",
+			},
+			want: xyz{Key: "legacy"},
+		},
+		{
+			name: "legacy via file variant",
+			env: map[string]string{
+				"LEGACY_KEY__FILE": "legacy",
+			},
+			fs: fstest.MapFS{
+				"legacy": {Data: []byte("legacy")},
+			},
+			want: xyz{Key: "legacy"},
+		},
+		{
+			name: "alias mutually exclusive with its own file variant",
+			env: map[string]string{
+				"PRIMARY_KEY":       "primary",
+				"PRIMARY_KEY__FILE": "primary",
+			},
+			fs: fstest.MapFS{
+				"primary": {Data: []byte("primary")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, performTest(tt))
+	}
+
+	requiredTests := []TestCase[required]{
+		{
+			name:    "required missing from every alias",
+			wantErr: true,
+		},
+		{
+			name: "required satisfied by a non-primary alias",
+			env: map[string]string{
+				"LEGACY_KEY": "legacy",
+			},
+			want: required{Key: "legacy"},
+		},
+	}
+	for _, tt := range requiredTests {
+		t.Run(tt.name, performTest(tt))
+	}
+}
+
+type envSetterID struct {
+	value string
+}
+
+func (e *envSetterID) UnmarshalEnv(value string) error {
+	e.value = "parsed:" + value
+	return nil
+}
+
+func Test_ExpandedTypes(t *testing.T) {
+	type wide struct {
+		Int8     int8              `env:"INT8"`
+		Uint     uint              `env:"UINT"`
+		Float    float64           `env:"FLOAT"`
+		Dur      time.Duration     `env:"DUR"`
+		At       time.Time         `env:"AT"`
+		AtCustom time.Time         `env:"AT_CUSTOM" env-layout:"2006-01-02"`
+		Loc      *time.Location    `env:"LOC"`
+		URL      *url.URL          `env:"URL"`
+		Tags     []string          `env:"TAGS"`
+		Ports    []int             `env:"PORTS" env-separator:";"`
+		Labels   map[string]string `env:"LABELS"`
+		Setter   envSetterID       `env:"SETTER"`
+	}
+
+	at, err := time.Parse(time.RFC3339, "2024-01-02T15:04:05Z")
+	if err != nil {
+		t.Fatalf("failed to parse reference time: %v", err)
+	}
+	atCustom, err := time.Parse("2006-01-02", "2024-01-02")
+	if err != nil {
+		t.Fatalf("failed to parse reference date: %v", err)
+	}
+	loc, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("failed to load reference location: %v", err)
+	}
+	u, err := url.Parse("https://example.com/path")
+	if err != nil {
+		t.Fatalf("failed to parse reference url: %v", err)
+	}
+
+	os.Setenv("INT8", "-12")
+	os.Setenv("UINT", "7")
+	os.Setenv("FLOAT", "3.5")
+	os.Setenv("DUR", "1h30m")
+	os.Setenv("AT", "2024-01-02T15:04:05Z")
+	os.Setenv("AT_CUSTOM", "2024-01-02")
+	os.Setenv("LOC", "UTC")
+	os.Setenv("URL", "https://example.com/path")
+	os.Setenv("TAGS", "a,b,c")
+	os.Setenv("PORTS", "80;443")
+	os.Setenv("LABELS", "k1:v1,k2:v2")
+	os.Setenv("SETTER", "raw")
+	defer func() {
+		for _, key := range []string{"INT8", "UINT", "FLOAT", "DUR", "AT", "AT_CUSTOM", "LOC", "URL", "TAGS", "PORTS", "LABELS", "SETTER"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	var got wide
+	y := Yadsec{secretsDir: "secrets/"}
+	if err := y.load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	want := wide{
+		Int8:     -12,
+		Uint:     7,
+		Float:    3.5,
+		Dur:      90 * time.Minute,
+		At:       at,
+		AtCustom: atCustom,
+		Loc:      loc,
+		URL:      u,
+		Tags:     []string{"a", "b", "c"},
+		Ports:    []int{80, 443},
+		Labels:   map[string]string{"k1": "v1", "k2": "v2"},
+		Setter:   envSetterID{value: "parsed:raw"},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+}
+
+func Test_ExpandedTypes_InvalidValues(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		val  string
+	}{
+		{name: "invalid duration", key: "DUR", val: "notaduration"},
+		{name: "invalid time", key: "AT", val: "notatime"},
+		{name: "invalid location", key: "LOC", val: "Nowhere/Nowhere"},
+		{name: "invalid url", key: "URL", val: "http://[::1"},
+		{name: "invalid map entry", key: "LABELS", val: "k1v1"},
+	}
+
+	type wide struct {
+		Dur    time.Duration     `env:"DUR"`
+		At     time.Time         `env:"AT"`
+		Loc    *time.Location    `env:"LOC"`
+		URL    *url.URL          `env:"URL"`
+		Labels map[string]string `env:"LABELS"`
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(tt.key, tt.val)
+			defer os.Unsetenv(tt.key)
+
+			var got wide
+			y := Yadsec{secretsDir: "secrets/"}
+			if err := y.load(&got); err == nil {
+				t.Errorf("expected an error but got nil")
+			}
+		})
+	}
+}
+
+func Test_Defaults(t *testing.T) {
+	type withDefault struct {
+		Str string `env:"STR" env-default:"fallback"`
+	}
+	type requiredWithDefault struct {
+		Str string `env:"STR,required" env-default:"fallback"`
+	}
+
+	tests := []TestCase[withDefault]{
+		{
+			name: "default applied when unset",
+			want: withDefault{Str: "fallback"},
+		},
+		{
+			name: "explicit value overrides default",
+			env: map[string]string{
+				"STR": "explicit",
+			},
+			want: withDefault{Str: "explicit"},
+		},
+		{
+			name: "file value overrides default",
+			env: map[string]string{
+				"STR__FILE": "hello",
+			},
+			fs: fstest.MapFS{
+				"hello": {Data: []byte("fromfile")},
+			},
+			want: withDefault{Str: "fromfile"},
+		},
+		{
+			name: "secret value overrides default",
+			env: map[string]string{
+				"STR__SECRET": "",
+			},
+			fs: fstest.MapFS{
+				"secrets/STR": {Data: []byte("fromsecret")},
+			},
+			want: withDefault{Str: "fromsecret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, performTest(tt))
+	}
+
+	requiredTests := []TestCase[requiredWithDefault]{
+		{
+			name: "default satisfies required",
+			want: requiredWithDefault{Str: "fallback"},
+		},
+	}
+	for _, tt := range requiredTests {
+		t.Run(tt.name, performTest(tt))
+	}
+}
+
+func Test_Usage(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST,required" env-description:"server listen address"`
+		Port int    `env:"PORT" env-default:"8080" env-description:"server listen port"`
+	}
+
+	var buf bytes.Buffer
+	if err := Usage(&config{}, &buf); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"HOST", "PORT", "true", "false", "8080", "server listen address", "server listen port"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected usage output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func Test_NestedConfig(t *testing.T) {
+	type DBConfig struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT" env-default:"5432"`
+	}
+	type HTTPConfig struct {
+		Addr string `env:"ADDR" env-default:"0.0.0.0:8080"`
+	}
+	type Config struct {
+		DB   DBConfig   `env:"DB_,prefix"`
+		HTTP HTTPConfig `env:"HTTP_,prefix"`
+	}
+
+	os.Setenv("DB_HOST", "db.internal")
+	os.Setenv("HTTP_ADDR", "127.0.0.1:9090")
+	defer os.Unsetenv("DB_HOST")
+	defer os.Unsetenv("HTTP_ADDR")
+
+	var got Config
+	y := Yadsec{secretsDir: "secrets/"}
+	if err := y.load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	want := Config{
+		DB:   DBConfig{Host: "db.internal", Port: 5432},
+		HTTP: HTTPConfig{Addr: "127.0.0.1:9090"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+}
+
+func Test_NestedConfig_RequiredPropagation(t *testing.T) {
+	type DBConfig struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		DB DBConfig `env:"DB_,prefix,required"`
+	}
+
+	var got Config
+	y := Yadsec{secretsDir: "secrets/"}
+	if err := y.load(&got); err == nil {
+		t.Errorf("expected an error but got nil")
+	}
+}
+
+func Test_NestedConfig_SameTypeSiblings(t *testing.T) {
+	type Endpoint struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		Primary Endpoint `env:"PRIMARY_,prefix"`
+		Replica Endpoint `env:"REPLICA_,prefix"`
+	}
+
+	os.Setenv("PRIMARY_HOST", "primary.internal")
+	os.Setenv("REPLICA_HOST", "replica.internal")
+	defer os.Unsetenv("PRIMARY_HOST")
+	defer os.Unsetenv("REPLICA_HOST")
+
+	var got Config
+	y := Yadsec{secretsDir: "secrets/"}
+	if err := y.load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	want := Config{
+		Primary: Endpoint{Host: "primary.internal"},
+		Replica: Endpoint{Host: "replica.internal"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+}
+
+func Test_NestedConfig_Embedded(t *testing.T) {
+	type Common struct {
+		Name string `env:"NAME"`
+	}
+	type Config struct {
+		Common
+		Port int `env:"PORT"`
+	}
+
+	os.Setenv("NAME", "svc")
+	os.Setenv("PORT", "8080")
+	defer os.Unsetenv("NAME")
+	defer os.Unsetenv("PORT")
+
+	var got Config
+	y := Yadsec{secretsDir: "secrets/"}
+	if err := y.load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	want := Config{Common: Common{Name: "svc"}, Port: 8080}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+}
+
+func Test_New_LayeredSources(t *testing.T) {
+	type cfg struct {
+		A string `env:"A"`
+		B string `env:"B"`
+		C string `env:"C"`
+	}
+
+	fsys := fstest.MapFS{
+		".env":        {Data: []byte("A=dotenv-a\nB=dotenv-b\n# a comment\n\n")},
+		"config.json": {Data: []byte(`{"B":"config-b","C":"config-c"}`)},
+	}
+
+	os.Setenv("A", "env-a")
+	defer os.Unsetenv("A")
+
+	y, err := New(WithFS(fsys), WithDotenv(".env"), WithConfigFile("config.json"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	want := cfg{
+		A: "env-a",
+		B: "dotenv-b",
+		C: "config-c",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %+v but got %+v", want, got)
+	}
+}
+
+func Test_New_DotenvBeatsSecret(t *testing.T) {
+	type cfg struct {
+		Key string `env:"KEY"`
+	}
+
+	fsys := fstest.MapFS{
+		".env":        {Data: []byte("KEY=from-dotenv\n")},
+		"secrets/KEY": {Data: []byte("from-secret")},
+	}
+
+	os.Setenv("KEY__SECRET", "")
+	defer os.Unsetenv("KEY__SECRET")
+
+	y, err := New(WithFS(fsys), WithDotenv(".env"), WithSecretsDir("secrets/"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Key != "from-dotenv" {
+		t.Errorf("expected dotenv value to win, got %q", got.Key)
+	}
+}
+
+func Test_New_SecretBeatsConfigFile(t *testing.T) {
+	type cfg struct {
+		Key string `env:"KEY"`
+	}
+
+	fsys := fstest.MapFS{
+		"secrets/KEY": {Data: []byte("from-secret")},
+		"config.json": {Data: []byte(`{"KEY":"from-config"}`)},
+	}
+
+	os.Setenv("KEY__SECRET", "")
+	defer os.Unsetenv("KEY__SECRET")
+
+	y, err := New(WithFS(fsys), WithSecretsDir("secrets/"), WithConfigFile("config.json"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Key != "from-secret" {
+		t.Errorf("expected secret value to win, got %q", got.Key)
+	}
+}
+
+func Test_New_ConfigFileFallback(t *testing.T) {
+	type cfg struct {
+		Key string `env:"KEY"`
+	}
+
+	fsys := fstest.MapFS{
+		"config.json": {Data: []byte(`{"KEY":"from-config"}`)},
+	}
+
+	y, err := New(WithFS(fsys), WithConfigFile("config.json"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Key != "from-config" {
+		t.Errorf("expected config file value, got %q", got.Key)
+	}
+}
+
+func Test_New_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.ini": {Data: []byte("key=value")},
+	}
+
+	if _, err := New(WithFS(fsys), WithDotenv("missing.env")); err == nil {
+		t.Errorf("expected an error for a missing dotenv file but got nil")
+	}
+	if _, err := New(WithFS(fsys), WithConfigFile("config.ini")); err == nil {
+		t.Errorf("expected an error for an unsupported config format but got nil")
+	}
+}
+
+func Test_New_ConfigFile_YAML(t *testing.T) {
+	type cfg struct {
+		Key string `env:"KEY"`
+	}
+
+	fsys := fstest.MapFS{
+		"config.yaml": {Data: []byte("KEY: \"from-yaml\" # comment\n")},
+	}
+
+	y, err := New(WithFS(fsys), WithConfigFile("config.yaml"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Key != "from-yaml" {
+		t.Errorf("expected yaml config value, got %q", got.Key)
+	}
+}
+
+func Test_New_ConfigFile_TOML(t *testing.T) {
+	type cfg struct {
+		Key string `env:"KEY"`
+	}
+
+	fsys := fstest.MapFS{
+		"config.toml": {Data: []byte("KEY = \"from-toml\"\n")},
+	}
+
+	y, err := New(WithFS(fsys), WithConfigFile("config.toml"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Key != "from-toml" {
+		t.Errorf("expected toml config value, got %q", got.Key)
+	}
+}
+
+func Test_New_ConfigFile_YAML_QuotedHash(t *testing.T) {
+	type cfg struct {
+		Greeting string `env:"GREETING"`
+	}
+
+	fsys := fstest.MapFS{
+		"config.yaml": {Data: []byte(`GREETING: "hello # world"` + "\n")},
+	}
+
+	y, err := New(WithFS(fsys), WithConfigFile("config.yaml"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Greeting != "hello # world" {
+		t.Errorf("expected quoted value containing %q to survive intact, got %q", "#", got.Greeting)
+	}
+}
+
+func Test_New_ConfigFile_YAML_RejectsNesting(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml": {Data: []byte("db:\n  host: x\n")},
+	}
+
+	if _, err := New(WithFS(fsys), WithConfigFile("config.yaml")); err == nil {
+		t.Errorf("expected an error for nested yaml but got nil")
+	}
+}
+
+func Test_New_ConfigFile_TOML_TrailingComment(t *testing.T) {
+	type cfg struct {
+		Key string `env:"KEY"`
+	}
+
+	fsys := fstest.MapFS{
+		"config.toml": {Data: []byte(`KEY = "from-toml" # note` + "\n")},
+	}
+
+	y, err := New(WithFS(fsys), WithConfigFile("config.toml"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	var got cfg
+	if err := y.Load(&got); err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+	if got.Key != "from-toml" {
+		t.Errorf("expected trailing comment to be stripped, got %q", got.Key)
+	}
+}
+
+type fakeClock struct {
+	ch chan time.Time
+}
+
+func (f *fakeClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	return f.ch, func() {}
+}
+
+func Test_Watch(t *testing.T) {
+	type cfg struct {
+		Key       string `env:"KEY"`
+		Bootstrap string `env:"BOOT,noreload"`
+	}
+
+	fsys := fstest.MapFS{
+		"secrets/KEY":  {Data: []byte("v1"), ModTime: time.Unix(1, 0)},
+		"secrets/BOOT": {Data: []byte("boot1"), ModTime: time.Unix(1, 0)},
+	}
+
+	os.Setenv("KEY__SECRET", "")
+	os.Setenv("BOOT__SECRET", "")
+	defer os.Unsetenv("KEY__SECRET")
+	defer os.Unsetenv("BOOT__SECRET")
+
+	y, err := New(WithFS(fsys), WithSecretsDir("secrets/"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	tick := make(chan time.Time)
+	var changes int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := Watch[cfg](ctx, y, func(err error) {
+		if err != nil {
+			t.Errorf("did not expect a reload error, but got one %v", err)
+		}
+		atomic.AddInt32(&changes, 1)
+	}, withClock(&fakeClock{ch: tick}))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	if got := w.Get(); got.Key != "v1" || got.Bootstrap != "boot1" {
+		t.Fatalf("unexpected initial snapshot %+v", got)
+	}
+
+	fsys["secrets/KEY"] = &fstest.MapFile{Data: []byte("v2"), ModTime: time.Unix(2, 0)}
+	fsys["secrets/BOOT"] = &fstest.MapFile{Data: []byte("boot2"), ModTime: time.Unix(2, 0)}
+
+	tick <- time.Time{}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&changes) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	got := w.Get()
+	if got.Key != "v2" {
+		t.Errorf("expected reload to pick up new secret value, got %q", got.Key)
+	}
+	if got.Bootstrap != "boot1" {
+		t.Errorf("expected noreload field to keep its bootstrap value, got %q", got.Bootstrap)
+	}
+}
+
+func Test_Watch_InitialLoadError(t *testing.T) {
+	type cfg struct {
+		Key string `env:"TEST_WATCH_MISSING_REQUIRED_KEY,required"`
+	}
+
+	y, err := New()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got one %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := Watch[cfg](ctx, y, func(error) {}); err == nil {
+		t.Errorf("expected an error but got nil")
+	}
+}
+
 func performTest[T comparable](tc TestCase[T]) func(*testing.T) {
 	return func(t *testing.T) {
 		for key, value := range tc.env {